@@ -0,0 +1,34 @@
+// Package db holds the GORM models for tables the API server reads and
+// writes through database/sql via the store package's query-builder SQL (see
+// pkg/api/server/store), while still relying on GORM's AutoMigrate to keep
+// the schema in sync with these struct definitions.
+package db
+
+import "time"
+
+// Record is a row of the records table: a serialized Result plus the typed
+// index columns ListResultsResult's typed query fields are pushed down
+// into, so common dashboard queries don't require a CEL-filtered scan of
+// the whole table.
+type Record struct {
+	ID       string `gorm:"primaryKey"`
+	Parent   string `gorm:"index;index:idx_records_parent_updated_time,priority:1"`
+	ResultID string
+	Name     string `gorm:"uniqueIndex"`
+	Data     []byte
+
+	// Status, Pipeline, Labels and UpdatedTime are promoted off the
+	// serialized Result so store.IterateTypedPage can filter and order on
+	// them in SQL; see recordIndexFields in pkg/api/server/v1alpha1. Labels
+	// is JSON text rather than a dialect-specific JSON column type, so the
+	// schema stays portable across sqlite/postgres/mysql; sqlStore's
+	// labelPredicate casts it inline where a dialect needs to query into it.
+	Status      string    `gorm:"index"`
+	Pipeline    string    `gorm:"index"`
+	Labels      string    `gorm:"type:text"`
+	UpdatedTime time.Time `gorm:"index:idx_records_parent_updated_time,priority:2"`
+}
+
+// TableName pins Record to "records", the table name store.Store's SQL
+// already assumes.
+func (Record) TableName() string { return "records" }
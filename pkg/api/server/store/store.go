@@ -0,0 +1,268 @@
+// Package store abstracts the SQL operations the API server runs against
+// the records table behind a single interface, so callers don't need to
+// know (or hardcode placeholders for) the underlying SQL dialect.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	dbmodel "github.com/tektoncd/results/pkg/api/server/db"
+)
+
+const recordsTable = "records"
+
+// Runner is the subset of *sql.DB / *sql.Tx that Store needs. Passing either
+// in lets callers choose whether an operation runs standalone or pinned to
+// an existing transaction (e.g. a read-only batch snapshot).
+type Runner interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Store is the set of SQL operations the API server needs over the records
+// table. Implementations build dialect-correct SQL via a query builder
+// rather than hand-rolled strings with hardcoded placeholders.
+type Store interface {
+	// Insert adds a new record.
+	Insert(ctx context.Context, db Runner, r *dbmodel.Record) error
+	// GetByName fetches a record by its fully qualified name.
+	GetByName(ctx context.Context, db Runner, name string) (*dbmodel.Record, error)
+	// Update overwrites the data and typed index columns for the record
+	// identified by r.Name.
+	Update(ctx context.Context, db Runner, r *dbmodel.Record) error
+	// Delete removes the record with the given name and reports how many
+	// rows were affected.
+	Delete(ctx context.Context, db Runner, name string) (int64, error)
+	// IteratePage returns up to limit records ordered by name, starting at
+	// (and including) start. An empty start begins at the first record.
+	IteratePage(ctx context.Context, db Runner, start string, limit int) ([]*dbmodel.Record, error)
+	// IterateTypedPage returns up to limit records matching filter, ordered
+	// by (updated_time desc, name) starting after cursor (nil begins at the
+	// first record), pushing the typed query fields down into the WHERE
+	// clause instead of requiring the caller to unmarshal and CEL-filter
+	// every row in the table.
+	IterateTypedPage(ctx context.Context, db Runner, filter TypedFilter, cursor *TypedCursor, limit int) ([]*dbmodel.Record, error)
+}
+
+// TypedCursor is the keyset IterateTypedPage resumes from: the
+// (updated_time, name) of the last row returned by the previous page. It
+// must match the ORDER BY updated_time DESC, name tuple exactly — seeking on
+// name alone while ordering by updated_time would skip or repeat rows
+// whenever two results share a name ordering that disagrees with their
+// updated_time ordering.
+type TypedCursor struct {
+	UpdatedTime time.Time
+	Name        string
+}
+
+// TypedFilter narrows ListResultsResult to the typed, indexable query fields
+// that can be pushed down into SQL, so CEL only has to run over the rows
+// that are already known to match. A zero-value TypedFilter matches every
+// record.
+type TypedFilter struct {
+	Status        []string
+	ParentPrefix  string
+	Pipeline      string
+	UpdatedSince  *time.Time
+	UpdatedUntil  *time.Time
+	LabelSelector map[string]string
+}
+
+// IsEmpty reports whether no typed field is set, meaning a query should fall
+// back to a CEL-only scan rather than this path.
+func (f TypedFilter) IsEmpty() bool {
+	return len(f.Status) == 0 && f.ParentPrefix == "" && f.Pipeline == "" &&
+		f.UpdatedSince == nil && f.UpdatedUntil == nil && len(f.LabelSelector) == 0
+}
+
+// New returns the Store implementation for driverName ("sqlite3", "postgres"
+// or "mysql"), each using the placeholder format that dialect expects.
+func New(driverName string) (Store, error) {
+	switch driverName {
+	case "sqlite3", "sqlite":
+		return &sqlStore{driver: "sqlite3", builder: sq.StatementBuilder.PlaceholderFormat(sq.Question)}, nil
+	case "postgres":
+		return &sqlStore{driver: "postgres", builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar)}, nil
+	case "mysql":
+		return &sqlStore{driver: "mysql", builder: sq.StatementBuilder.PlaceholderFormat(sq.Question)}, nil
+	default:
+		return nil, fmt.Errorf("store: unsupported driver %q", driverName)
+	}
+}
+
+// sqlStore is a Store implementation shared by every dialect; the
+// placeholder format built into builder, and driver itself for the few
+// operations (like label containment) with no portable SQL, change between
+// them.
+type sqlStore struct {
+	driver  string
+	builder sq.StatementBuilderType
+}
+
+// labelPredicate returns the dialect-correct way to test whether the labels
+// JSON-text column has key set to value: Postgres has native jsonb
+// containment (labels is stored as plain text to stay portable across
+// dialects, so this casts inline rather than requiring a jsonb column type),
+// MySQL has JSON_CONTAINS, and SQLite (built with the json1 extension, as
+// modernc/mattn sqlite3 drivers are) has json_extract.
+func (s *sqlStore) labelPredicate(key, value string) sq.Sqlizer {
+	switch s.driver {
+	case "postgres":
+		return sq.Expr("labels::jsonb @> ?", fmt.Sprintf(`{%q:%q}`, key, value))
+	case "mysql":
+		return sq.Expr("JSON_CONTAINS(labels, JSON_QUOTE(?), ?)", value, "$."+key)
+	default: // sqlite3
+		return sq.Expr("json_extract(labels, ?) = ?", "$."+key, value)
+	}
+}
+
+func (s *sqlStore) Insert(ctx context.Context, db Runner, r *dbmodel.Record) error {
+	query, args, err := s.builder.Insert(recordsTable).
+		Columns("id", "parent", "result_id", "name", "data", "status", "pipeline", "labels", "updated_time").
+		Values(r.ID, r.Parent, r.ResultID, r.Name, r.Data, r.Status, r.Pipeline, r.Labels, r.UpdatedTime).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert record: %w", err)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetByName(ctx context.Context, db Runner, name string) (*dbmodel.Record, error) {
+	query, args, err := s.builder.Select("id", "parent", "result_id", "name", "data").
+		From(recordsTable).
+		Where(sq.Eq{"name": name}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	r := &dbmodel.Record{}
+	row := db.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(&r.ID, &r.Parent, &r.ResultID, &r.Name, &r.Data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to scan record: %w", err)
+	}
+	return r, nil
+}
+
+func (s *sqlStore) Update(ctx context.Context, db Runner, r *dbmodel.Record) error {
+	query, args, err := s.builder.Update(recordsTable).
+		Set("data", r.Data).
+		Set("status", r.Status).
+		Set("pipeline", r.Pipeline).
+		Set("labels", r.Labels).
+		Set("updated_time", r.UpdatedTime).
+		Where(sq.Eq{"name": r.Name}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update record: %w", err)
+	}
+	return nil
+}
+
+func (s *sqlStore) Delete(ctx context.Context, db Runner, name string) (int64, error) {
+	query, args, err := s.builder.Delete(recordsTable).
+		Where(sq.Eq{"name": name}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete record: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (s *sqlStore) IteratePage(ctx context.Context, db Runner, start string, limit int) ([]*dbmodel.Record, error) {
+	q := s.builder.Select("name", "data").From(recordsTable).OrderBy("name").Limit(uint64(limit))
+	if start != "" {
+		q = q.Where(sq.GtOrEq{"name": start})
+	}
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*dbmodel.Record
+	for rows.Next() {
+		r := &dbmodel.Record{}
+		if err := rows.Scan(&r.Name, &r.Data); err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func (s *sqlStore) IterateTypedPage(ctx context.Context, db Runner, filter TypedFilter, cursor *TypedCursor, limit int) ([]*dbmodel.Record, error) {
+	q := s.builder.Select("name", "data", "updated_time").From(recordsTable)
+	if len(filter.Status) > 0 {
+		q = q.Where(sq.Eq{"status": filter.Status})
+	}
+	if filter.ParentPrefix != "" {
+		q = q.Where(sq.Like{"parent": filter.ParentPrefix + "%"})
+	}
+	if filter.Pipeline != "" {
+		q = q.Where(sq.Eq{"pipeline": filter.Pipeline})
+	}
+	if filter.UpdatedSince != nil {
+		q = q.Where(sq.GtOrEq{"updated_time": *filter.UpdatedSince})
+	}
+	if filter.UpdatedUntil != nil {
+		q = q.Where(sq.LtOrEq{"updated_time": *filter.UpdatedUntil})
+	}
+	for k, v := range filter.LabelSelector {
+		q = q.Where(s.labelPredicate(k, v))
+	}
+	if cursor != nil {
+		// Keyset predicate for ORDER BY updated_time DESC, name: the next
+		// row is either strictly older, or tied on updated_time and
+		// lexically later by name.
+		q = q.Where(sq.Or{
+			sq.Lt{"updated_time": cursor.UpdatedTime},
+			sq.And{sq.Eq{"updated_time": cursor.UpdatedTime}, sq.Gt{"name": cursor.Name}},
+		})
+	}
+	// Ordered for the "last N changed in parent" dashboard queries this
+	// filter exists for; pair with an index on (parent, updated_time desc, name).
+	q = q.OrderBy("updated_time DESC", "name").Limit(uint64(limit))
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*dbmodel.Record
+	for rows.Next() {
+		r := &dbmodel.Record{}
+		if err := rows.Scan(&r.Name, &r.Data, &r.UpdatedTime); err != nil {
+			return nil, fmt.Errorf("failed to scan record: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
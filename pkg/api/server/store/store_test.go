@@ -0,0 +1,53 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLabelPredicateDialects(t *testing.T) {
+	for _, tc := range []struct {
+		driver      string
+		wantInSQL   string
+		wantInArgs0 string
+	}{
+		{driver: "postgres", wantInSQL: "labels::jsonb @>", wantInArgs0: `{"team":"infra"}`},
+		{driver: "mysql", wantInSQL: "JSON_CONTAINS(labels", wantInArgs0: "infra"},
+		{driver: "sqlite3", wantInSQL: "json_extract(labels", wantInArgs0: "$.team"},
+	} {
+		t.Run(tc.driver, func(t *testing.T) {
+			s := &sqlStore{driver: tc.driver}
+			sql, args, err := s.labelPredicate("team", "infra").ToSql()
+			if err != nil {
+				t.Fatalf("ToSql() returned error: %v", err)
+			}
+			if !strings.Contains(sql, tc.wantInSQL) {
+				t.Errorf("sql = %q, want it to contain %q", sql, tc.wantInSQL)
+			}
+			if len(args) == 0 {
+				t.Fatalf("expected at least one arg, got none")
+			}
+			if args[0] != tc.wantInArgs0 {
+				t.Errorf("args[0] = %v, want %v", args[0], tc.wantInArgs0)
+			}
+		})
+	}
+}
+
+func TestTypedFilterIsEmpty(t *testing.T) {
+	if !(TypedFilter{}).IsEmpty() {
+		t.Error("zero-value TypedFilter should be empty")
+	}
+	if (TypedFilter{Pipeline: "build"}).IsEmpty() {
+		t.Error("TypedFilter with Pipeline set should not be empty")
+	}
+	if (TypedFilter{LabelSelector: map[string]string{"team": "infra"}}).IsEmpty() {
+		t.Error("TypedFilter with a LabelSelector set should not be empty")
+	}
+}
+
+func TestNewUnsupportedDriver(t *testing.T) {
+	if _, err := New("mongo"); err == nil {
+		t.Error("New(\"mongo\") expected an error, got nil")
+	}
+}
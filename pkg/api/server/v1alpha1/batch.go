@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/uuid"
+
+	"github.com/tektoncd/results/pkg/api/server/store"
+	pb "github.com/tektoncd/results/proto/v1alpha1/results_go_proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"knative.dev/pkg/logging"
+)
+
+// batchIDMetadataKey is the incoming gRPC metadata key clients set to pin a
+// GetResult/ListResultsResult/WatchResults call to a previously opened
+// read-only batch.
+const batchIDMetadataKey = "batch_id"
+
+// defaultBatchIdleTimeout bounds how long an unused batch handle is kept
+// open before it is auto-expired, so a client that never calls EndBatch
+// can't leak a connection forever.
+const defaultBatchIdleTimeout = 5 * time.Minute
+
+// batch is a read-only transaction pinned to a batch_id so a client can make
+// several reads that observe a single consistent snapshot. *sql.Tx is not
+// safe for concurrent use, so batch itself implements store.Runner and
+// serializes every query against execMu; expireBatch takes that same lock
+// before rolling back, so it waits out any query already in flight instead
+// of yanking the tx from under it.
+type batch struct {
+	id     string
+	tx     *sql.Tx
+	execMu sync.Mutex
+
+	timerMu sync.Mutex
+	timer   *time.Timer
+}
+
+// ExecContext, QueryContext and QueryRowContext make *batch satisfy
+// store.Runner, serializing each call against the pinned transaction.
+func (b *batch) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	b.execMu.Lock()
+	defer b.execMu.Unlock()
+	return b.tx.ExecContext(ctx, query, args...)
+}
+
+func (b *batch) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	b.execMu.Lock()
+	defer b.execMu.Unlock()
+	return b.tx.QueryContext(ctx, query, args...)
+}
+
+func (b *batch) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	b.execMu.Lock()
+	defer b.execMu.Unlock()
+	return b.tx.QueryRowContext(ctx, query, args...)
+}
+
+// touch resets the idle timer, keeping the batch alive for another
+// idleTimeout.
+func (b *batch) touch(idleTimeout time.Duration, onExpire func()) {
+	b.timerMu.Lock()
+	defer b.timerMu.Unlock()
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.timer = time.AfterFunc(idleTimeout, onExpire)
+}
+
+// BeginReadOnlyBatch opens a repeatable-read (Postgres) or deferred (SQLite)
+// transaction and returns an opaque batch_id bound to it. Clients attach the
+// batch_id as metadata on subsequent GetResult/ListResultsResult/
+// WatchResults calls to read a consistent snapshot; writes made with a
+// batch_id attached are rejected.
+func (s *Server) BeginReadOnlyBatch(ctx context.Context, req *pb.BeginReadOnlyBatchRequest) (*pb.BeginReadOnlyBatchResponse, error) {
+	logger, requestID := newRequestLogger(ctx, "BeginReadOnlyBatch")
+
+	opts := &sql.TxOptions{ReadOnly: true}
+	if s.isPostgres {
+		opts.Isolation = sql.LevelRepeatableRead
+	}
+	tx, err := s.db.BeginTx(ctx, opts)
+	if err != nil {
+		logger.Errorf("failed to begin batch transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin batch (request_id: %s): %w", requestID, err)
+	}
+
+	id := uuid.New().String()
+	b := &batch{id: id, tx: tx}
+	s.batches.Store(id, b)
+	b.touch(s.batchIdleTimeout, func() { s.expireBatch(id) })
+
+	logger.Infow("batch opened", "batch_id", id)
+	return &pb.BeginReadOnlyBatchResponse{BatchId: id}, nil
+}
+
+// EndBatch releases the transaction backing a batch_id, returning its
+// connection to the pool. Ending an already-expired or unknown batch is not
+// an error, since the client may be racing the idle timeout.
+func (s *Server) EndBatch(ctx context.Context, req *pb.EndBatchRequest) (*empty.Empty, error) {
+	logger, _ := newRequestLogger(ctx, "EndBatch")
+	s.expireBatch(req.GetBatchId())
+	logger.Infow("batch ended", "batch_id", req.GetBatchId())
+	return nil, nil
+}
+
+// expireBatch rolls back and forgets the batch with the given id, if it's
+// still open. Safe to call more than once for the same id. Blocks on execMu,
+// so a query already in flight against this batch finishes (and any new one
+// arriving after LoadAndDelete can no longer find the batch to start one)
+// before the transaction is rolled back out from under it.
+func (s *Server) expireBatch(id string) {
+	v, ok := s.batches.LoadAndDelete(id)
+	if !ok {
+		return
+	}
+	b := v.(*batch)
+	b.timerMu.Lock()
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.timerMu.Unlock()
+
+	b.execMu.Lock()
+	defer b.execMu.Unlock()
+	if err := b.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		logging.FromContext(context.Background()).Errorf("failed to roll back expired batch %s: %v", id, err)
+	}
+}
+
+// batchFromContext looks up the batch pinned by the incoming batch_id
+// metadata, if any, refreshing its idle timer on every use.
+func (s *Server) batchFromContext(ctx context.Context) (*batch, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	ids := md.Get(batchIDMetadataKey)
+	if len(ids) == 0 || ids[0] == "" {
+		return nil, false
+	}
+	v, ok := s.batches.Load(ids[0])
+	if !ok {
+		return nil, false
+	}
+	b := v.(*batch)
+	b.touch(s.batchIdleTimeout, func() { s.expireBatch(b.id) })
+	return b, true
+}
+
+// rejectIfBatched returns a FailedPrecondition error if ctx carries a
+// batch_id, since batches are read-only by construction.
+func rejectIfBatched(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	if ids := md.Get(batchIDMetadataKey); len(ids) > 0 && ids[0] != "" {
+		return status.Errorf(codes.FailedPrecondition, "batch %s is read-only", ids[0])
+	}
+	return nil
+}
+
+// readerFor returns the store.Runner a read RPC should use: the pinned
+// batch (which serializes access to its transaction) for a batched call, or
+// the server's shared *sql.DB otherwise.
+func (s *Server) readerFor(ctx context.Context) store.Runner {
+	if b, ok := s.batchFromContext(ctx); ok {
+		return b
+	}
+	return s.db
+}
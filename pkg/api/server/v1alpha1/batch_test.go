@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBatchTouchResetsIdleTimer(t *testing.T) {
+	b := &batch{id: "test-batch"}
+
+	var expired int32
+	b.touch(50*time.Millisecond, func() { atomic.AddInt32(&expired, 1) })
+
+	time.Sleep(20 * time.Millisecond)
+	b.touch(50*time.Millisecond, func() { atomic.AddInt32(&expired, 1) }) // refresh before expiry
+
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&expired) != 0 {
+		t.Fatalf("batch expired before its idle timeout was refreshed")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if atomic.LoadInt32(&expired) != 1 {
+		t.Fatalf("expired = %d, want 1 after the idle timeout elapsed", atomic.LoadInt32(&expired))
+	}
+}
+
+func TestBatchFromContext(t *testing.T) {
+	s := &Server{batches: &sync.Map{}, batchIdleTimeout: time.Minute}
+	b := &batch{id: "abc"}
+	s.batches.Store(b.id, b)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(batchIDMetadataKey, "abc"))
+	got, ok := s.batchFromContext(ctx)
+	if !ok || got != b {
+		t.Fatalf("batchFromContext() = %v, %v, want %v, true", got, ok, b)
+	}
+
+	if _, ok := s.batchFromContext(context.Background()); ok {
+		t.Error("batchFromContext() on a context without batch_id metadata should report false")
+	}
+
+	unknown := metadata.NewIncomingContext(context.Background(), metadata.Pairs(batchIDMetadataKey, "does-not-exist"))
+	if _, ok := s.batchFromContext(unknown); ok {
+		t.Error("batchFromContext() for an unknown batch_id should report false")
+	}
+}
+
+func TestRejectIfBatched(t *testing.T) {
+	if err := rejectIfBatched(context.Background()); err != nil {
+		t.Errorf("rejectIfBatched() on an unbatched context returned %v, want nil", err)
+	}
+
+	batched := metadata.NewIncomingContext(context.Background(), metadata.Pairs(batchIDMetadataKey, "abc"))
+	if err := rejectIfBatched(batched); err == nil {
+		t.Error("rejectIfBatched() on a batched context expected an error, got nil")
+	}
+}
+
+func TestExpireBatchUnknownID(t *testing.T) {
+	s := &Server{batches: &sync.Map{}, batchIdleTimeout: time.Minute}
+	// No batch is stored under this id, so expireBatch must return without
+	// touching (and panicking on) a transaction.
+	s.expireBatch("never-stored")
+}
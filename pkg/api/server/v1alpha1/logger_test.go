@@ -0,0 +1,23 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+)
+
+func TestNewRequestLoggerGeneratesAUniqueRequestID(t *testing.T) {
+	ctx := logging.WithLogger(context.Background(), zap.NewNop().Sugar())
+
+	_, id1 := newRequestLogger(ctx, "GetResult")
+	_, id2 := newRequestLogger(ctx, "GetResult")
+
+	if id1 == "" {
+		t.Fatal("newRequestLogger() returned an empty request_id")
+	}
+	if id1 == id2 {
+		t.Errorf("two calls to newRequestLogger() returned the same request_id %q", id1)
+	}
+}
@@ -3,16 +3,24 @@ package server
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/cel-go/cel"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	resultscel "github.com/tektoncd/results/pkg/api/server/cel"
 	dbmodel "github.com/tektoncd/results/pkg/api/server/db"
 	"github.com/tektoncd/results/pkg/api/server/db/pagination"
+	"github.com/tektoncd/results/pkg/api/server/store"
 	ppb "github.com/tektoncd/results/proto/pipeline/v1beta1/pipeline_go_proto"
 	pb "github.com/tektoncd/results/proto/v1alpha1/results_go_proto"
 	mask "go.chromium.org/luci/common/proto/mask"
@@ -20,23 +28,63 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
+	"knative.dev/pkg/logging"
 )
 
 const (
 	listResultsDefaultPageSize = 50
 	listResultsMaximumPageSize = 10000
+
+	// requestIDHeader is the gRPC response header clients can read to
+	// correlate a failed call with the corresponding server log lines.
+	requestIDHeader = "request_id"
 )
 
 // Server with implementation of API server
 type Server struct {
 	pb.UnimplementedResultsServer
-	env *cel.Env
-	gdb *gorm.DB
-	db  *sql.DB
+	env   *cel.Env
+	gdb   *gorm.DB
+	db    *sql.DB
+	store store.Store
+
+	// isPostgres is true when gdb is backed by Postgres and selects
+	// dialect-specific SQL. WatchResults always fans events out through
+	// broker; when pqListener is set, writes reach broker via LISTEN/NOTIFY.
+	isPostgres bool
+	pqListener *pq.Listener
+	broker     *eventBroker
+
+	// batches holds open read-only snapshots keyed by batch_id; see batch.go.
+	// A pointer so copying Server (UpdateResult/DeleteResult take it by
+	// value) doesn't copy the underlying lock.
+	batches          *sync.Map
+	batchIdleTimeout time.Duration
+}
+
+// newRequestLogger generates a request_id for this call, sets it as a gRPC
+// response header so the client can hand it to us for support, and returns
+// a logger carrying it (and any other stable fields) on every line.
+func newRequestLogger(ctx context.Context, rpc string, fields ...zap.Field) (*zap.SugaredLogger, string) {
+	requestID := uuid.New().String()
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID)); err != nil {
+		logging.FromContext(ctx).Warnf("failed to set %s header: %v", requestIDHeader, err)
+	}
+	logger := logging.FromContext(ctx).Desugar().
+		With(append(fields, zap.String("rpc", rpc), zap.String("request_id", requestID))...).
+		Sugar()
+	return logger, requestID
 }
 
 // CreateResult receives CreateResultRequest from clients and save it to local Sqlite Server.
 func (s *Server) CreateResult(ctx context.Context, req *pb.CreateResultRequest) (*pb.Result, error) {
+	start := time.Now()
+	logger, requestID := newRequestLogger(ctx, "CreateResult", zap.String("parent", req.GetParent()))
+
+	if err := rejectIfBatched(ctx); err != nil {
+		return nil, err
+	}
+
 	r := req.GetResult()
 	name := uuid.New().String()
 	r.Name = fmt.Sprintf("%s/results/%s", req.GetParent(), name)
@@ -44,8 +92,15 @@ func (s *Server) CreateResult(ctx context.Context, req *pb.CreateResultRequest)
 	// serialize data and insert it into database.
 	b, err := proto.Marshal(r)
 	if err != nil {
-		log.Printf("result marshaling error: %v", err)
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
+		logger.Errorf("result marshaling error: %v", err)
+		return nil, fmt.Errorf("failed to marshal result (request_id: %s): %w", requestID, err)
+	}
+
+	resultStatus, pipeline, labels := recordIndexFields(r)
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		logger.Errorf("labels marshaling error: %v", err)
+		return nil, fmt.Errorf("failed to marshal labels (request_id: %s): %w", requestID, err)
 	}
 
 	// Slightly confusing since this is CreateResult, but this maps better to
@@ -61,35 +116,48 @@ func (s *Server) CreateResult(ctx context.Context, req *pb.CreateResultRequest)
 		// This should be the parent-less name, but allow for now for compatibility.
 		Name: r.Name,
 		Data: b,
-	}
-	if err := s.gdb.WithContext(ctx).Create(record).Error; err != nil {
+		// Typed index columns ListResultsResult's typed query fields are
+		// pushed down into, so common dashboard queries don't need a
+		// CEL-filtered scan of the whole table.
+		Status:      resultStatus,
+		Pipeline:    pipeline,
+		Labels:      string(labelsJSON),
+		UpdatedTime: time.Now(),
+	}
+	if err := s.store.Insert(ctx, s.db, record); err != nil {
+		logger.Errorf("failed to insert record: %v", err)
 		return nil, err
 	}
 
+	s.publishEvent(ctx, pb.ResultEvent_ADDED, r)
+	logger.Infow("result created", "name", r.GetName(), "duration_ms", time.Since(start).Milliseconds())
 	return r, nil
 }
 
 // GetResult received GetResultRequest from users and return Result back to users
 func (s *Server) GetResult(ctx context.Context, req *pb.GetResultRequest) (*pb.Result, error) {
-	r, err := s.getResultByID(req.GetName())
+	logger, requestID := newRequestLogger(ctx, "GetResult", zap.String("name", req.GetName()))
+	r, err := s.getResultByID(ctx, s.readerFor(ctx), req.GetName())
 	if err != nil {
-		return nil, fmt.Errorf("failed to find a result: %w", err)
+		logger.Errorf("failed to find result: %v", err)
+		return nil, fmt.Errorf("failed to find a result (request_id: %s): %w", requestID, err)
 	}
 	return r, nil
 }
 
 // UpdateResult receives Result and FieldMask from client and uses them to update records in local Sqlite Server.
 func (s Server) UpdateResult(ctx context.Context, req *pb.UpdateResultRequest) (*pb.Result, error) {
-	// Find corresponding Result in the database according to results_id.
-	tx, err := s.db.Begin()
-	if err != nil {
-		log.Printf("failed to begin a transaction: %v", err)
-		return nil, fmt.Errorf("failed to update a result: %w", err)
+	start := time.Now()
+	logger, requestID := newRequestLogger(ctx, "UpdateResult", zap.String("name", req.GetName()))
+
+	if err := rejectIfBatched(ctx); err != nil {
+		return nil, err
 	}
 
-	prev, err := s.getResultByID(req.GetName())
+	prev, err := s.getResultByID(ctx, s.db, req.GetName())
 	if err != nil {
-		return nil, fmt.Errorf("failed to find a result: %w", err)
+		logger.Errorf("failed to find result: %v", err)
+		return nil, fmt.Errorf("failed to find a result (request_id: %s): %w", requestID, err)
 	}
 
 	r := proto.Clone(prev).(*pb.Result)
@@ -101,76 +169,82 @@ func (s Server) UpdateResult(ctx context.Context, req *pb.UpdateResultRequest) (
 		msk, err := mask.FromFieldMask(req.GetUpdateMask(), r, false, true)
 		// Return NotFound error to client field is invalid
 		if err != nil {
-			log.Printf("failed to convert fieldmask to mask: %v", err)
-			return nil, status.Errorf(codes.NotFound, "field in fieldmask not found in result")
+			logger.Errorf("failed to convert fieldmask to mask: %v", err)
+			return nil, status.Errorf(codes.NotFound, "field in fieldmask not found in result (request_id: %s)", requestID)
 		}
 		if err := msk.Merge(req.GetResult(), r); err != nil {
-			log.Printf("failed to merge new result into old result: %v", err)
-			return nil, fmt.Errorf("failed to update result: %w", err)
+			logger.Errorf("failed to merge new result into old result: %v", err)
+			return nil, fmt.Errorf("failed to update result (request_id: %s): %w", requestID, err)
 		}
 	}
 
 	// Do any most-mask validation to make sure we are not mutating any immutable fields.
 	if r.GetName() != prev.GetName() {
-		return prev, status.Error(codes.InvalidArgument, "result name cannot be changed")
+		return prev, status.Errorf(codes.InvalidArgument, "result name cannot be changed (request_id: %s)", requestID)
 	}
 	if r.GetCreatedTime() != prev.GetCreatedTime() {
-		return prev, status.Error(codes.InvalidArgument, "created time cannot be changed")
+		return prev, status.Errorf(codes.InvalidArgument, "created time cannot be changed (request_id: %s)", requestID)
 	}
 
 	// Write result back to database.
 	b, err := proto.Marshal(r)
 	if err != nil {
-		log.Println("result marshaling error: ", err)
-		return nil, fmt.Errorf("result marshaling error: %w", err)
+		logger.Errorf("result marshaling error: %v", err)
+		return nil, fmt.Errorf("result marshaling error (request_id: %s): %w", requestID, err)
 	}
-	statement, err := s.db.Prepare("UPDATE records SET data = ? WHERE name = ?")
+	resultStatus, pipeline, labels := recordIndexFields(r)
+	labelsJSON, err := json.Marshal(labels)
 	if err != nil {
-		log.Printf("failed to update a existing result: %v", err)
-		return nil, fmt.Errorf("failed to update a exsiting result: %w", err)
-	}
-	if _, err := statement.Exec(b, r.GetName()); err != nil {
-		if err := tx.Rollback(); err != nil {
-			log.Printf("failed to rollback transaction: %v", err)
-		}
-		log.Printf("failed to execute update of a new result: %v", err)
-		return nil, fmt.Errorf("failed to execute update of a new result: %w", err)
-	}
-	if err := tx.Commit(); err != nil {
-		log.Printf("failed to commit transaction: %v", err)
-	}
+		logger.Errorf("labels marshaling error: %v", err)
+		return nil, fmt.Errorf("labels marshaling error (request_id: %s): %w", requestID, err)
+	}
+	if err := s.store.Update(ctx, s.db, &dbmodel.Record{
+		Name:        r.GetName(),
+		Data:        b,
+		Status:      resultStatus,
+		Pipeline:    pipeline,
+		Labels:      string(labelsJSON),
+		UpdatedTime: time.Now(),
+	}); err != nil {
+		logger.Errorf("failed to execute update of a new result: %v", err)
+		return nil, fmt.Errorf("failed to execute update of a new result (request_id: %s): %w", requestID, err)
+	}
+	s.publishEvent(ctx, pb.ResultEvent_MODIFIED, r)
+	logger.Infow("result updated", "duration_ms", time.Since(start).Milliseconds())
 	return r, nil
 }
 
 // DeleteResult receives DeleteResult request from users and delete Result in local Sqlite Server.
 func (s Server) DeleteResult(ctx context.Context, req *pb.DeleteResultRequest) (*empty.Empty, error) {
-	statement, err := s.db.Prepare("DELETE FROM records WHERE name = ?")
-	if err != nil {
-		log.Printf("failed to create delete statement: %v", err)
-		return nil, fmt.Errorf("failed to create delete statement: %w", err)
-	}
-	results, err := statement.Exec(req.GetName())
-	if err != nil {
-		log.Printf("failed to execute delete statement: %v", err)
-		return nil, fmt.Errorf("failed to execute delete statement: %w", err)
+	logger, requestID := newRequestLogger(ctx, "DeleteResult", zap.String("name", req.GetName()))
+
+	if err := rejectIfBatched(ctx); err != nil {
+		return nil, err
 	}
-	affect, err := results.RowsAffected()
+
+	affect, err := s.store.Delete(ctx, s.db, req.GetName())
 	if err != nil {
-		log.Printf("failed to retrieve results: %v", err)
-		return nil, fmt.Errorf("failed to retrieve results: %w", err)
+		logger.Errorf("failed to execute delete statement: %v", err)
+		return nil, fmt.Errorf("failed to execute delete statement (request_id: %s): %w", requestID, err)
 	}
 	if affect == 0 {
-		return nil, status.Errorf(codes.NotFound, "Result not found")
+		return nil, status.Errorf(codes.NotFound, "Result not found (request_id: %s)", requestID)
 	}
+	s.publishEvent(ctx, pb.ResultEvent_DELETED, &pb.Result{Name: req.GetName()})
+	logger.Info("result deleted")
 	return nil, nil
 }
 
 // ListResultsResult receives a ListResultRequest from users and return to users a list of Results according to the query
 func (s *Server) ListResultsResult(ctx context.Context, req *pb.ListResultsRequest) (*pb.ListResultsResponse, error) {
+	begin := time.Now()
+	logger, requestID := newRequestLogger(ctx, "ListResultsResult",
+		zap.Int32("page_size", req.GetPageSize()), zap.Int("filter_len", len(req.GetFilter())))
+
 	// checks and refines the pageSize
 	pageSize := int(req.GetPageSize())
 	if pageSize < 0 {
-		return nil, status.Error(codes.InvalidArgument, "PageSize should be greater than 0")
+		return nil, status.Errorf(codes.InvalidArgument, "PageSize should be greater than 0 (request_id: %s)", requestID)
 	} else if pageSize == 0 {
 		pageSize = listResultsDefaultPageSize
 	} else if pageSize > listResultsMaximumPageSize {
@@ -182,46 +256,146 @@ func (s *Server) ListResultsResult(ctx context.Context, req *pb.ListResultsReque
 	if t := req.GetPageToken(); t != "" {
 		name, filter, err := pagination.DecodeToken(t)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid PageToken: %v", err))
+			return nil, status.Errorf(codes.InvalidArgument, "invalid PageToken (request_id: %s): %v", requestID, err)
 		}
 		if req.GetFilter() != filter {
-			return nil, status.Error(codes.InvalidArgument, "use a different CEL `filter` from the last page.")
+			return nil, status.Errorf(codes.InvalidArgument, "use a different CEL `filter` from the last page. (request_id: %s)", requestID)
 		}
 		start = name
 	}
 
 	prg, err := resultscel.ParseFilter(s.env, req.GetFilter())
 	if err != nil {
-		log.Printf("program construction error: %s", err)
-		return nil, status.Errorf(codes.InvalidArgument, "Error occurred during filter checking step, no Results found for the query string due to invalid field, invalid function to evaluate filter or missing double quotes around field value, please try to enter a query with correct type again: %v", err)
+		logger.Errorf("program construction error: %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "Error occurred during filter checking step, no Results found for the query string due to invalid field, invalid function to evaluate filter or missing double quotes around field value, please try to enter a query with correct type again (request_id: %s): %v", requestID, err)
 	}
 
-	tx, err := s.db.Begin()
-	if err != nil {
-		return nil, err
+	typedFilter := store.TypedFilter{
+		Status:        req.GetStatus(),
+		ParentPrefix:  req.GetParent(),
+		Pipeline:      req.GetPipeline(),
+		LabelSelector: req.GetLabelSelector(),
+	}
+	if ts := req.GetUpdatedSince(); ts != nil {
+		t := ts.AsTime()
+		typedFilter.UpdatedSince = &t
+	}
+	if ts := req.GetUpdatedUntil(); ts != nil {
+		t := ts.AsTime()
+		typedFilter.UpdatedUntil = &t
 	}
+
 	// always request one more result to know whether next page exists.
-	results, err := getFilteredPaginatedResults(tx, pageSize+1, start, prg)
+	var results []*pb.Result
+	var typedCursors []store.TypedCursor // parallel to results; only populated for the typed path
+	var rowsScanned int
+	if !typedFilter.IsEmpty() {
+		// IterateTypedPage orders by (updated_time desc, name), so the
+		// keyset it pages on has to carry both, not just name; start (if
+		// any) was encoded as such a cursor by the previous page below.
+		var cursor *store.TypedCursor
+		if start != "" {
+			updatedTime, name, err := decodeTypedCursor(start)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid PageToken (request_id: %s): %v", requestID, err)
+			}
+			cursor = &store.TypedCursor{UpdatedTime: updatedTime, Name: name}
+		}
+		// Typed fields already narrow the SQL query, but a CEL filter (if
+		// also set) can still reject some of those rows, so this adaptively
+		// re-queries past them the same way getFilteredPaginatedResults does.
+		results, typedCursors, rowsScanned, err = getTypedFilteredResults(ctx, logger, s.store, s.readerFor(ctx), typedFilter, pageSize+1, cursor, prg)
+	} else {
+		results, rowsScanned, err = getFilteredPaginatedResults(ctx, logger, s.store, s.readerFor(ctx), pageSize+1, start, prg)
+	}
 	if err != nil {
 		return nil, err
 	}
-	if err := tx.Commit(); err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to commit the query transaction: %v", err))
-	}
+	logger.Infow("results listed", "rows_scanned", rowsScanned, "rows_matched", len(results), "duration_ms", time.Since(begin).Milliseconds())
 
 	if len(results) > pageSize {
 		// there exists next page, generate the nextPageToken, and drop the last one of the results.
 		nextResult := results[len(results)-1]
 		results := results[:len(results)-1]
-		if nextPageToken, err := pagination.EncodeToken(nextResult.GetName(), req.GetFilter()); err == nil {
+		nextName := nextResult.GetName()
+		if !typedFilter.IsEmpty() {
+			// Encode the full (updated_time, name) keyset, matching IterateTypedPage's order.
+			// The cursor is exclusive, so it is that of the last result kept on
+			// this page; the dropped extra result is then the next page's first.
+			nextCursor := typedCursors[len(typedCursors)-2]
+			nextName = encodeTypedCursor(nextCursor.UpdatedTime, nextCursor.Name)
+		}
+		if nextPageToken, err := pagination.EncodeToken(nextName, req.GetFilter()); err == nil {
 			return &pb.ListResultsResponse{Results: results, NextPageToken: nextPageToken}, nil
 		}
 	}
 	return &pb.ListResultsResponse{Results: results}, nil
 }
 
+// typedCursorSep separates the updated_time and name halves of a typed-page
+// cursor. pagination.EncodeToken/DecodeToken treat their first argument as
+// an opaque identifier, so this composite string rides through them
+// unchanged; it unit-separates rather than using a printable character
+// since a Result name won't contain one.
+const typedCursorSep = "\x1f"
+
+// encodeTypedCursor packs the keyset IterateTypedPage needs to resume after
+// this row: its order is (updated_time desc, name), so paging on name alone
+// would skip or repeat rows relative to that order.
+func encodeTypedCursor(updatedTime time.Time, name string) string {
+	return updatedTime.UTC().Format(time.RFC3339Nano) + typedCursorSep + name
+}
+
+// decodeTypedCursor is the inverse of encodeTypedCursor.
+func decodeTypedCursor(cursor string) (time.Time, string, error) {
+	parts := strings.SplitN(cursor, typedCursorSep, 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", fmt.Errorf("malformed typed page cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("malformed typed page cursor timestamp: %w", err)
+	}
+	return t, parts[1], nil
+}
+
+// recordIndexFields derives the typed columns that get promoted onto the
+// records table from a Result's executions, so ListResultsResult's typed
+// query fields (status, pipeline, label_selector) can be pushed down into
+// SQL instead of requiring a CEL scan of every row. The first execution that
+// carries a value wins, since in practice a Result has a single TaskRun or
+// PipelineRun.
+func recordIndexFields(r *pb.Result) (status, pipeline string, labels map[string]string) {
+	for _, e := range r.Executions {
+		if pr := e.GetPipelineRun(); pr != nil {
+			if pipeline == "" {
+				pipeline = pr.GetName()
+			}
+			if labels == nil {
+				labels = pr.GetLabels()
+			}
+			if status == "" {
+				if conditions := pr.GetStatus().GetConditions(); len(conditions) > 0 {
+					status = conditions[0].GetReason()
+				}
+			}
+		}
+		if tr := e.GetTaskRun(); tr != nil {
+			if labels == nil {
+				labels = tr.GetLabels()
+			}
+			if status == "" {
+				if conditions := tr.GetStatus().GetConditions(); len(conditions) > 0 {
+					status = conditions[0].GetReason()
+				}
+			}
+		}
+	}
+	return status, pipeline, labels
+}
+
 // Check if the result can be reserved.
-func matchCelFilter(r *pb.Result, prg cel.Program) (bool, error) {
+func matchCelFilter(ctx context.Context, r *pb.Result, prg cel.Program) (bool, error) {
 	if prg == nil {
 		return true, nil
 	}
@@ -242,7 +416,7 @@ func matchCelFilter(r *pb.Result, prg cel.Program) (bool, error) {
 			"pipelinerun": pipelinerun,
 		})
 		if err != nil {
-			log.Printf("failed to evaluate the expression: %v", err)
+			logging.FromContext(ctx).Errorf("failed to evaluate the expression: %v", err)
 			return false, status.Errorf(codes.InvalidArgument, "Error occurred during filter evaluation step, no Results found for the query string due to invalid field, invalid function to evaluate filter or missing double quotes around field value, please try to enter a query with correct type again: %v", err)
 		}
 		if out.Value() == true {
@@ -266,46 +440,51 @@ func matchCelFilter(r *pb.Result, prg cel.Program) (bool, error) {
 //                  batchSize = pageSize/last_ratio
 // The less the previous ratio is, the bigger the upcoming batch_size is. Then the queried time
 // is significantly decreased.
-func getFilteredPaginatedResults(tx *sql.Tx, pageSize int, start string, prg cel.Program) (results []*pb.Result, err error) {
+func getFilteredPaginatedResults(ctx context.Context, logger *zap.SugaredLogger, st store.Store, db store.Runner, pageSize int, start string, prg cel.Program) (results []*pb.Result, rowsScanned int, err error) {
 	var lastName string
 	//var ratio float32 = 1
 	batcher := pagination.NewBatcher(pageSize, listResultsDefaultPageSize, listResultsMaximumPageSize)
 	for len(results) < pageSize {
 		// If didn't get enought results.
 		batchSize := batcher.Next()
-		var rows *sql.Rows
-		if lastName == "" {
-			if start != "" {
-				rows, err = tx.Query("SELECT name, data FROM records WHERE name >= ? ORDER BY name LIMIT ? ", start, batchSize)
-			} else {
-				rows, err = tx.Query("SELECT name, data FROM records ORDER BY name LIMIT ?", batchSize)
-			}
-		} else {
-			rows, err = tx.Query("SELECT name, data FROM records WHERE name > ? ORDER BY name LIMIT ? ", lastName, batchSize)
+		from := lastName
+		if from == "" {
+			from = start
 		}
+		// After the first batch, fetch one extra row to make up for the
+		// already-consumed start row dropped below.
+		limit := batchSize
+		if lastName != "" {
+			limit++
+		}
+		records, err := st.IteratePage(ctx, db, from, limit)
 		if err != nil {
-			log.Printf("failed to query on database: %v", err)
-			return nil, status.Errorf(codes.Internal, "failed to query results: %v", err)
+			logger.Errorf("failed to query on database: %v", err)
+			return nil, rowsScanned, status.Errorf(codes.Internal, "failed to query results: %v", err)
+		}
+		if lastName != "" && len(records) > 0 && records[0].Name == lastName {
+			// IteratePage is inclusive of start; drop the row we already consumed.
+			records = records[1:]
+		}
+		if len(records) > batchSize {
+			records = records[:batchSize]
 		}
 
 		var (
 			batchGot     int // number of items returned from the query. Always <= less than batchSize.
 			batchMatched int // number of items returned from the query that satisfy the filter condition. Always <= batchGot.
 		)
-		for rows.Next() {
+		for _, rec := range records {
 			batchGot++
-			var b []byte
-			if err := rows.Scan(&lastName, &b); err != nil {
-				log.Printf("failed to scan a row in query results: %v", err)
-				return nil, status.Errorf(codes.Internal, "failed to read result data: %v", err)
-			}
+			rowsScanned++
+			lastName = rec.Name
 			r := &pb.Result{}
-			if err := proto.Unmarshal(b, r); err != nil {
-				log.Printf("unmarshaling error: %v", err)
-				return nil, status.Errorf(codes.Internal, "failed to parse result data: %v", err)
+			if err := proto.Unmarshal(rec.Data, r); err != nil {
+				logger.Errorf("unmarshaling error: %v", err)
+				return nil, rowsScanned, status.Errorf(codes.Internal, "failed to parse result data: %v", err)
 			}
 			// filter the results one by one
-			if ok, _ := matchCelFilter(r, prg); ok {
+			if ok, _ := matchCelFilter(ctx, r, prg); ok {
 				batchMatched++
 				results = append(results, r)
 				if len(results) >= pageSize {
@@ -313,6 +492,7 @@ func getFilteredPaginatedResults(tx *sql.Tx, pageSize int, start string, prg cel
 				}
 			}
 		}
+		logger.Debugw("batch scanned", "batch_size", batchSize, "batch_got", batchGot, "batch_matched", batchMatched)
 		if batchGot < batchSize {
 			// No more data in database.
 			break
@@ -320,36 +500,80 @@ func getFilteredPaginatedResults(tx *sql.Tx, pageSize int, start string, prg cel
 		// update batcher to determine the next batch size.
 		batcher.Update(batchMatched, batchGot)
 	}
-	return results, nil
+	return results, rowsScanned, nil
+}
+
+// getTypedFilteredResults serves ListResultsResult when the request sets any
+// typed query field (status, parent prefix, pipeline, updated_since/until,
+// label_selector). The WHERE clause already narrows the rows, but CEL, if
+// the request also set a filter, still runs client-side over what the WHERE
+// clause matched, so it can reject rows the same way getFilteredPaginatedResults'
+// CEL-only path does. This adaptively re-queries with an advancing keyset
+// cursor, the same shape as getFilteredPaginatedResults, so a CEL filter that
+// rejects rows from the typed result set doesn't silently truncate the page.
+func getTypedFilteredResults(ctx context.Context, logger *zap.SugaredLogger, st store.Store, db store.Runner, filter store.TypedFilter, pageSize int, cursor *store.TypedCursor, prg cel.Program) (results []*pb.Result, cursors []store.TypedCursor, rowsScanned int, err error) {
+	cur := cursor
+	batcher := pagination.NewBatcher(pageSize, listResultsDefaultPageSize, listResultsMaximumPageSize)
+	for len(results) < pageSize {
+		batchSize := batcher.Next()
+		records, err := st.IterateTypedPage(ctx, db, filter, cur, batchSize)
+		if err != nil {
+			logger.Errorf("failed to query on database: %v", err)
+			return nil, nil, rowsScanned, status.Errorf(codes.Internal, "failed to query results: %v", err)
+		}
+
+		var (
+			batchGot     int
+			batchMatched int
+		)
+		for _, rec := range records {
+			batchGot++
+			rowsScanned++
+			// Cursor comes from the record (the store's index columns), not
+			// the unmarshaled Result, since updated_time is promoted onto
+			// records but isn't itself a field of the serialized Result.
+			// Advance it over every scanned row, matched or not, so the next
+			// batch doesn't re-query rows CEL already rejected.
+			cur = &store.TypedCursor{UpdatedTime: rec.UpdatedTime, Name: rec.Name}
+			r := &pb.Result{}
+			if err := proto.Unmarshal(rec.Data, r); err != nil {
+				logger.Errorf("unmarshaling error: %v", err)
+				return nil, nil, rowsScanned, status.Errorf(codes.Internal, "failed to parse result data: %v", err)
+			}
+			if ok, _ := matchCelFilter(ctx, r, prg); ok {
+				batchMatched++
+				results = append(results, r)
+				cursors = append(cursors, *cur)
+				if len(results) >= pageSize {
+					break
+				}
+			}
+		}
+		logger.Debugw("typed batch scanned", "batch_size", batchSize, "batch_got", batchGot, "batch_matched", batchMatched)
+		if batchGot < batchSize {
+			// No more data in database.
+			break
+		}
+		batcher.Update(batchMatched, batchGot)
+	}
+	return results, cursors, rowsScanned, nil
 }
 
 // GetResultByID is the helper function to get a Result by results_id
-func (s Server) getResultByID(name string) (*pb.Result, error) {
-	rows, err := s.db.Query("SELECT data FROM records WHERE name = ?", name)
+func (s Server) getResultByID(ctx context.Context, db store.Runner, name string) (*pb.Result, error) {
+	logger := logging.FromContext(ctx)
+	rec, err := s.store.GetByName(ctx, db, name)
 	if err != nil {
-		log.Printf("failed to query on database: %v", err)
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "result not found")
+		}
+		logger.Errorf("failed to query on database: %v", err)
 		return nil, fmt.Errorf("failed to query on a result: %w", err)
 	}
 	result := &pb.Result{}
-	rowNum := 0
-	for rows.Next() {
-		var b []byte
-		rowNum++
-		if rowNum >= 2 {
-			log.Println("Warning: multiple rows found")
-			break
-		}
-		if err := rows.Scan(&b); err != nil {
-			log.Printf("error scanning rows: %v", err)
-			return nil, fmt.Errorf("error scanning rows: %w", err)
-		}
-		if err := proto.Unmarshal(b, result); err != nil {
-			log.Printf("unmarshaling error: %v", err)
-			return nil, fmt.Errorf("failed to unmarshal result: %w", err)
-		}
-	}
-	if rowNum == 0 {
-		return nil, status.Error(codes.NotFound, "result not found")
+	if err := proto.Unmarshal(rec.Data, result); err != nil {
+		logger.Errorf("unmarshaling error: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
 	}
 	return result, nil
 }
@@ -358,16 +582,49 @@ func (s Server) getResultByID(name string) (*pb.Result, error) {
 func New(gdb *gorm.DB) (*Server, error) {
 	env, err := resultscel.NewEnv()
 	if err != nil {
-		log.Fatalf("failed to create environment for filter: %v", err)
+		return nil, fmt.Errorf("failed to create environment for filter: %w", err)
 	}
 	db, err := gdb.DB()
 	if err != nil {
 		return nil, err
 	}
+	// AutoMigrate is this repo's schema-management mechanism: it creates the
+	// records table (and the status/pipeline/labels/updated_time columns
+	// store.Store filters, orders and writes on) if it doesn't already
+	// exist, and is a no-op against a database that's already current.
+	if err := gdb.AutoMigrate(&dbmodel.Record{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate records table: %w", err)
+	}
+	st, err := store.New(gdb.Name())
+	if err != nil {
+		return nil, err
+	}
 	srv := &Server{
-		gdb: gdb,
-		db:  db,
-		env: env,
+		gdb:              gdb,
+		db:               db,
+		env:              env,
+		store:            st,
+		isPostgres:       gdb.Name() == "postgres",
+		broker:           newEventBroker(),
+		batches:          &sync.Map{},
+		batchIdleTimeout: defaultBatchIdleTimeout,
+	}
+	// isPostgres only selects dialect-specific SQL (e.g. batch.go's
+	// transaction isolation level); WatchResults' publish/subscribe path is
+	// keyed off pqListener instead, since that's only set once
+	// NewWithListener attaches a live LISTEN connection.
+	return srv, nil
+}
+
+// NewWithListener is like New but additionally wires up a Postgres
+// LISTEN/NOTIFY subscription for WatchResults. l must already be listening
+// on resultsEventsChannel.
+func NewWithListener(gdb *gorm.DB, l *pq.Listener) (*Server, error) {
+	srv, err := New(gdb)
+	if err != nil {
+		return nil, err
 	}
+	srv.pqListener = l
+	srv.startPQPump(l)
 	return srv, nil
 }
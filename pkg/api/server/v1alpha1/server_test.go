@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypedCursorRoundTrip(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	encoded := encodeTypedCursor(want, "default/results/abc-123")
+
+	gotTime, gotName, err := decodeTypedCursor(encoded)
+	if err != nil {
+		t.Fatalf("decodeTypedCursor(%q) returned error: %v", encoded, err)
+	}
+	if !gotTime.Equal(want) {
+		t.Errorf("decoded time = %v, want %v", gotTime, want)
+	}
+	if gotName != "default/results/abc-123" {
+		t.Errorf("decoded name = %q, want %q", gotName, "default/results/abc-123")
+	}
+}
+
+func TestDecodeTypedCursorMalformed(t *testing.T) {
+	for _, cursor := range []string{
+		"",
+		"no-separator-here",
+		"not-a-time" + typedCursorSep + "default/results/abc-123",
+	} {
+		if _, _, err := decodeTypedCursor(cursor); err == nil {
+			t.Errorf("decodeTypedCursor(%q) expected an error, got nil", cursor)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	resultscel "github.com/tektoncd/results/pkg/api/server/cel"
+	"github.com/tektoncd/results/pkg/api/server/db/pagination"
+	pb "github.com/tektoncd/results/proto/v1alpha1/results_go_proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// resultsEventsChannel is the Postgres LISTEN/NOTIFY channel that
+	// CreateResult/UpdateResult/DeleteResult publish to after commit.
+	resultsEventsChannel = "results_events"
+
+	// watchSubscriberBuffer bounds how many events we'll queue for a slow
+	// watcher before dropping the subscriber and forcing it to re-list.
+	watchSubscriberBuffer = 256
+
+	// watchBookmarkInterval is how often we send a bookmark event on an
+	// otherwise idle stream so watchers know where they can resume from.
+	watchBookmarkInterval = 30 * time.Second
+)
+
+// eventBroker is an in-process fan-out of ResultEvents. Every watcher
+// subscribes through it, on both SQLite and Postgres: on Postgres,
+// startPQPump is the sole reader of pqListener.Notify and republishes each
+// decoded event here, so fan-out to N concurrent watchers doesn't depend on
+// which goroutine happens to read the next notification off that one shared
+// channel.
+type eventBroker struct {
+	mu   sync.Mutex
+	subs map[string]chan *pb.ResultEvent
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subs: make(map[string]chan *pb.ResultEvent)}
+}
+
+// subscribe registers a new subscriber and returns its id, channel, and an
+// unsubscribe func the caller must invoke when done watching.
+func (b *eventBroker) subscribe() (string, <-chan *pb.ResultEvent, func()) {
+	id := uuid.New().String()
+	ch := make(chan *pb.ResultEvent, watchSubscriberBuffer)
+	b.mu.Lock()
+	b.subs[id] = ch
+	b.mu.Unlock()
+	return id, ch, func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	}
+}
+
+// publish fans an event out to every subscriber, dropping (rather than
+// blocking on) any subscriber whose buffer is full.
+func (b *eventBroker) publish(logger *zap.SugaredLogger, e *pb.ResultEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			logger.Warnf("watch: dropping slow subscriber %s", id)
+			delete(b.subs, id)
+			close(ch)
+		}
+	}
+}
+
+// publishEvent notifies watchers of a Result change. When pqListener is set,
+// only a small reference (kind + resume token) goes out via NOTIFY, since
+// Postgres hard-caps a NOTIFY payload at 8000 bytes and a real Result is
+// commonly several KB — startPQPump re-reads the row before republishing the
+// full event into the local broker. Without a listener it fans the full
+// event out directly to local in-process subscribers. This must key off the
+// same condition subscribeForWatch and startPQPump rely on (pqListener !=
+// nil), not isPostgres, since isPostgres is set as soon as New() sees a
+// Postgres gorm.DB even before NewWithListener attaches a listener.
+func (s *Server) publishEvent(ctx context.Context, kind pb.ResultEvent_EventType, r *pb.Result) {
+	logger := logging.FromContext(ctx)
+	e := &pb.ResultEvent{Kind: kind, Result: r, ResumeToken: r.GetName()}
+	if s.pqListener != nil {
+		ref, err := proto.Marshal(&pb.ResultEvent{Kind: kind, ResumeToken: r.GetName()})
+		if err != nil {
+			logger.Errorf("watch: failed to marshal event reference for NOTIFY: %v", err)
+			return
+		}
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf("NOTIFY %s, '%x'", resultsEventsChannel, ref)); err != nil {
+			logger.Errorf("watch: failed to NOTIFY on %s: %v", resultsEventsChannel, err)
+		}
+		return
+	}
+	if s.broker != nil {
+		s.broker.publish(logger, e)
+	}
+}
+
+// startPQPump is the single reader of l.Notify for the server's lifetime.
+// Each NOTIFY only carries a kind + resume token (see publishEvent), so for
+// anything but a delete it re-reads the row before republishing the full
+// event through s.broker, so every concurrent watcher sees every event
+// rather than each watcher racing to read off the one shared channel
+// round-robin. Called once, from NewWithListener.
+func (s *Server) startPQPump(l *pq.Listener) {
+	logger := logging.FromContext(context.Background())
+	go func() {
+		for n := range l.Notify {
+			if n == nil {
+				continue
+			}
+			raw, err := hex.DecodeString(n.Extra)
+			if err != nil {
+				logger.Errorf("watch: failed to hex-decode NOTIFY payload: %v", err)
+				continue
+			}
+			ref := &pb.ResultEvent{}
+			if err := proto.Unmarshal(raw, ref); err != nil {
+				logger.Errorf("watch: failed to unmarshal NOTIFY payload: %v", err)
+				continue
+			}
+
+			e := ref
+			if ref.GetKind() != pb.ResultEvent_DELETED {
+				r, err := s.getResultByID(context.Background(), s.db, ref.GetResumeToken())
+				if err != nil {
+					logger.Errorf("watch: failed to re-read %s after NOTIFY: %v", ref.GetResumeToken(), err)
+					continue
+				}
+				e = &pb.ResultEvent{Kind: ref.GetKind(), Result: r, ResumeToken: ref.GetResumeToken()}
+			}
+			s.broker.publish(logger, e)
+		}
+	}()
+}
+
+// WatchResults streams create/update/delete events for Results matching the
+// request's CEL filter. It first replays a snapshot starting at PageToken
+// using the same pagination machinery as ListResultsResult, then switches to
+// live events from NOTIFY (Postgres) or the in-process broker (SQLite).
+func (s *Server) WatchResults(req *pb.WatchResultsRequest, stream pb.Results_WatchResultsServer) error {
+	ctx := stream.Context()
+	logger := logging.FromContext(ctx).With(zap.String("rpc", "WatchResults"), zap.String("parent", req.GetParent()))
+
+	prg, err := resultscel.ParseFilter(s.env, req.GetFilter())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	var start string
+	if t := req.GetPageToken(); t != "" {
+		name, filter, err := pagination.DecodeToken(t)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid PageToken: %v", err)
+		}
+		if req.GetFilter() != filter {
+			return status.Error(codes.InvalidArgument, "use a different CEL `filter` from the last page.")
+		}
+		start = name
+	}
+
+	// Subscribe before replaying the snapshot so we don't miss events that
+	// land between the snapshot read and the subscription taking effect.
+	id, events, unsubscribe := s.subscribeForWatch(ctx)
+	defer unsubscribe()
+
+	snapshot, _, err := getFilteredPaginatedResults(ctx, logger, s.store, s.readerFor(ctx), listResultsMaximumPageSize, start, prg)
+	if err != nil {
+		return err
+	}
+	for _, r := range snapshot {
+		if err := stream.Send(&pb.ResultEvent{Kind: pb.ResultEvent_ADDED, Result: r, ResumeToken: r.GetName()}); err != nil {
+			return err
+		}
+	}
+	logger.Infow("watch snapshot replayed", "rows_matched", len(snapshot))
+
+	ticker := time.NewTicker(watchBookmarkInterval)
+	defer ticker.Stop()
+	var lastToken string
+	if len(snapshot) > 0 {
+		lastToken = snapshot[len(snapshot)-1].GetName()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := stream.Send(&pb.ResultEvent{Kind: pb.ResultEvent_BOOKMARK, ResumeToken: lastToken}); err != nil {
+				return err
+			}
+		case e, ok := <-events:
+			if !ok {
+				return status.Errorf(codes.Aborted, "watch %s fell behind, re-list and resume", id)
+			}
+			ok, err := matchCelFilter(ctx, e.GetResult(), prg)
+			if err != nil {
+				return err
+			}
+			if !ok && e.GetKind() != pb.ResultEvent_DELETED {
+				continue
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+			lastToken = e.GetResumeToken()
+		}
+	}
+}
+
+// subscribeForWatch returns a channel of events that stays subscribed until
+// the returned unsubscribe func is called; ctx does not end it. Both
+// SQLite and Postgres watchers subscribe through the same in-process broker;
+// on Postgres, startPQPump is what feeds it from NOTIFY.
+func (s *Server) subscribeForWatch(ctx context.Context) (string, <-chan *pb.ResultEvent, func()) {
+	return s.broker.subscribe()
+}
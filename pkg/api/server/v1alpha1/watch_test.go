@@ -0,0 +1,77 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/tektoncd/results/proto/v1alpha1/results_go_proto"
+	"go.uber.org/zap"
+)
+
+func TestEventBrokerPublishFanOut(t *testing.T) {
+	b := newEventBroker()
+	logger := zap.NewNop().Sugar()
+
+	_, ch1, unsub1 := b.subscribe()
+	defer unsub1()
+	_, ch2, unsub2 := b.subscribe()
+	defer unsub2()
+
+	want := &pb.ResultEvent{Kind: pb.ResultEvent_ADDED, ResumeToken: "default/results/abc"}
+	b.publish(logger, want)
+
+	for i, ch := range []<-chan *pb.ResultEvent{ch1, ch2} {
+		select {
+		case got := <-ch:
+			if got.GetResumeToken() != want.GetResumeToken() {
+				t.Errorf("subscriber %d got resume token %q, want %q", i, got.GetResumeToken(), want.GetResumeToken())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber %d did not receive the published event", i)
+		}
+	}
+}
+
+func TestEventBrokerDropsSlowSubscriber(t *testing.T) {
+	b := newEventBroker()
+	logger := zap.NewNop().Sugar()
+
+	id, ch, unsub := b.subscribe()
+	defer unsub()
+
+	for i := 0; i < watchSubscriberBuffer+1; i++ {
+		b.publish(logger, &pb.ResultEvent{ResumeToken: "fill"})
+	}
+
+	b.mu.Lock()
+	_, stillSubscribed := b.subs[id]
+	b.mu.Unlock()
+	if stillSubscribed {
+		t.Error("expected the slow subscriber to be dropped once its buffer filled")
+	}
+
+	// The channel should have been closed when the subscriber was dropped.
+	for range ch {
+	}
+}
+
+func TestEventBrokerUnsubscribe(t *testing.T) {
+	b := newEventBroker()
+	id, _, unsub := b.subscribe()
+
+	b.mu.Lock()
+	_, ok := b.subs[id]
+	b.mu.Unlock()
+	if !ok {
+		t.Fatalf("subscribe() did not register subscriber %s", id)
+	}
+
+	unsub()
+
+	b.mu.Lock()
+	_, ok = b.subs[id]
+	b.mu.Unlock()
+	if ok {
+		t.Error("unsubscribe did not remove the subscriber")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
 	pb "github.com/tektoncd/results/proto/v1alpha1/results_go_proto"
 	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
@@ -22,6 +23,7 @@ func NewController(ctx context.Context, cmw configmap.Watcher, client pb.Results
 		logger:            logger,
 		client:            client,
 		pipelineclientset: pipelineclientset,
+		kubeclientset:     kubeclient.Get(ctx),
 	}
 
 	impl := controller.NewImpl(c, c.logger, pipeline.PipelineRunControllerName)
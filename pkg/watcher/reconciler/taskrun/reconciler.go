@@ -0,0 +1,79 @@
+package taskrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	clientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"github.com/tektoncd/results/pkg/api/server/v1alpha1/convert"
+	pb "github.com/tektoncd/results/proto/v1alpha1/results_go_proto"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/apis"
+)
+
+// Reconciler implements the knative reconciler.Interface for TaskRuns. It
+// forwards TaskRun status to the Results API as the TaskRun progresses.
+type Reconciler struct {
+	logger            *zap.SugaredLogger
+	client            pb.ResultsClient
+	pipelineclientset clientset.Interface
+	kubeclientset     kubernetes.Interface
+}
+
+// ReconcileKind reports the TaskRun's current status to the Results API by
+// calling CreateResult on every reconcile; it does not look up or update a
+// Result created by an earlier reconcile of the same TaskRun.
+func (r *Reconciler) ReconcileKind(ctx context.Context, tr *v1beta1.TaskRun) error {
+	result, err := r.toResult(ctx, tr)
+	if err != nil {
+		return fmt.Errorf("failed to build Result for TaskRun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+
+	if _, err := r.client.CreateResult(ctx, &pb.CreateResultRequest{
+		Parent: tr.Namespace,
+		Result: result,
+	}); err != nil {
+		return fmt.Errorf("failed to send Result for TaskRun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+	return nil
+}
+
+// toResult converts a TaskRun into the Result that should be sent to the
+// Results API, merging in any results extracted from the sidecar log stream.
+func (r *Reconciler) toResult(ctx context.Context, tr *v1beta1.TaskRun) (*pb.Result, error) {
+	// ReconcileKind fires on every status update, including while the
+	// TaskRun is still running. extractSidecarResults opens the sidecar's
+	// log stream and scans it to completion, so calling it before the
+	// TaskRun (and its sidecar) have actually terminated would block this
+	// reconcile worker for the rest of the pod's lifetime. Only attempt
+	// extraction once the TaskRun has a terminal Succeeded condition.
+	if cond := tr.Status.GetCondition(apis.ConditionSucceeded); mergeSidecarResultsAnnotation(tr) && cond != nil && !cond.IsUnknown() {
+		extracted, malformed, err := r.extractSidecarResults(ctx, tr)
+		if malformed > 0 {
+			r.logger.Warnf("TaskRun %s/%s: skipped %d malformed sidecar result line(s)", tr.Namespace, tr.Name, malformed)
+		}
+		// Keep whatever was decoded before a size cap stopped the scan; only
+		// the remaining (unread) results are actually lost.
+		tr.Status.TaskRunResults = mergeTaskRunResults(tr.Status.TaskRunResults, extracted)
+		if err != nil {
+			r.logger.Warnf("failed to extract sidecar results for TaskRun %s/%s: %v", tr.Namespace, tr.Name, err)
+			var sizeErr *MaxResultSizeExceeded
+			if errors.As(err, &sizeErr) {
+				appendSidecarResultsCondition(tr, err)
+			}
+		}
+	}
+
+	trpb, err := convert.ToTaskRunProto(tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert TaskRun %s/%s to proto: %w", tr.Namespace, tr.Name, err)
+	}
+	return &pb.Result{
+		Executions: []*pb.Execution{{
+			Execution: &pb.Execution_TaskRun{TaskRun: trpb},
+		}},
+	}, nil
+}
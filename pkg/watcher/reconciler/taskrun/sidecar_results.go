@@ -0,0 +1,160 @@
+package taskrun
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// extractFromAnnotation opts a TaskRun into sidecar-log results
+	// extraction. The only currently supported value is "sidecar-logs".
+	extractFromAnnotation  = "results.tekton.dev/extract-from"
+	extractFromSidecarLogs = "sidecar-logs"
+
+	// resultsSidecarContainerName is the container whose log stream is
+	// scanned for result envelopes.
+	resultsSidecarContainerName = "results-sidecar"
+
+	// maxResultSize and maxTaskRunResultsSize bound how much data we'll
+	// accept per result and per TaskRun, respectively, from the sidecar.
+	maxResultSize         = 4 * 1024
+	maxTaskRunResultsSize = 32 * 1024
+)
+
+// MaxResultSizeExceeded is returned (and surfaced as a condition on the
+// reported Result) when a sidecar-emitted result is too large to accept.
+type MaxResultSizeExceeded struct {
+	StepName, ResultName string
+	Size, Max            int
+}
+
+func (e *MaxResultSizeExceeded) Error() string {
+	return fmt.Sprintf("result %s/%s is %d bytes, exceeds max of %d", e.StepName, e.ResultName, e.Size, e.Max)
+}
+
+// sidecarResultsTruncatedConditionType marks a TaskRun whose sidecar-log
+// results were cut off by a MaxResultSizeExceeded cap, so that's visible on
+// the object itself rather than only in reconciler logs.
+const sidecarResultsTruncatedConditionType apis.ConditionType = "SidecarResultsTruncated"
+
+// appendSidecarResultsCondition records err (a *MaxResultSizeExceeded) as a
+// False condition on tr. The results decoded before the cap was hit are
+// still reported; this documents that the rest were not.
+func appendSidecarResultsCondition(tr *v1beta1.TaskRun, err error) {
+	tr.Status.Status.Conditions = append(tr.Status.Status.Conditions, apis.Condition{
+		Type:    sidecarResultsTruncatedConditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  "MaxResultSizeExceeded",
+		Message: err.Error(),
+	})
+}
+
+// sidecarResultEnvelope is one line of the results sidecar's log stream.
+type sidecarResultEnvelope struct {
+	StepName   string `json:"stepName"`
+	ResultName string `json:"resultName"`
+	Value      string `json:"value"`
+	Type       string `json:"type"`
+}
+
+// mergeSidecarResultsAnnotation reports whether tr opted in to sidecar-log
+// results extraction.
+func mergeSidecarResultsAnnotation(tr *v1beta1.TaskRun) bool {
+	return tr.Annotations[extractFromAnnotation] == extractFromSidecarLogs
+}
+
+// extractSidecarResults reads the results sidecar's log stream for tr and
+// decodes it into TaskRunResults, along with a count of malformed lines it
+// skipped. It stops once the sidecar container has terminated or the size
+// cap is hit, and is cancellable via ctx. On a size-cap error the results
+// decoded so far are still returned, since only the unread remainder is
+// actually lost.
+func (r *Reconciler) extractSidecarResults(ctx context.Context, tr *v1beta1.TaskRun) ([]v1beta1.TaskRunResult, int, error) {
+	req := r.kubeclientset.CoreV1().Pods(tr.Namespace).GetLogs(tr.Status.PodName, &corev1.PodLogOptions{
+		Container: resultsSidecarContainerName,
+		Follow:    true,
+	})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open sidecar log stream: %w", err)
+	}
+	defer stream.Close()
+
+	return decodeSidecarResults(bufio.NewScanner(stream))
+}
+
+// decodeSidecarResults scans envelopes off sc, deduping by StepName/ResultName
+// (last write wins) and enforcing the per-result and per-TaskRun size caps.
+// It returns the results decoded so far, and how many malformed lines were
+// skipped, even when it also returns an error: a size-cap error means
+// scanning stopped early, not that nothing was decoded.
+func decodeSidecarResults(sc *bufio.Scanner) ([]v1beta1.TaskRunResult, int, error) {
+	type key struct{ step, name string }
+	byKey := map[key]v1beta1.TaskRunResult{}
+	order := []key{}
+	var total, malformed int
+
+	toResults := func() []v1beta1.TaskRunResult {
+		results := make([]v1beta1.TaskRunResult, 0, len(order))
+		for _, k := range order {
+			results = append(results, byKey[k])
+		}
+		return results
+	}
+
+	for sc.Scan() {
+		line := sc.Bytes()
+		var env sidecarResultEnvelope
+		if err := json.Unmarshal(line, &env); err != nil {
+			malformed++
+			continue
+		}
+		if len(env.Value) > maxResultSize {
+			return toResults(), malformed, &MaxResultSizeExceeded{StepName: env.StepName, ResultName: env.ResultName, Size: len(env.Value), Max: maxResultSize}
+		}
+		total += len(env.Value)
+		if total > maxTaskRunResultsSize {
+			return toResults(), malformed, &MaxResultSizeExceeded{StepName: env.StepName, ResultName: env.ResultName, Size: total, Max: maxTaskRunResultsSize}
+		}
+		k := key{env.StepName, env.ResultName}
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = v1beta1.TaskRunResult{
+			Name:  env.ResultName,
+			Type:  v1beta1.ResultsType(env.Type),
+			Value: *v1beta1.NewStructuredValues(env.Value),
+		}
+	}
+	if err := sc.Err(); err != nil && err != io.EOF {
+		return toResults(), malformed, fmt.Errorf("failed to read sidecar log stream: %w", err)
+	}
+
+	return toResults(), malformed, nil
+}
+
+// mergeTaskRunResults merges extracted results into existing, deduping by
+// result Name with last-write-wins semantics, consistent with how the
+// sidecar envelopes themselves are deduped.
+func mergeTaskRunResults(existing, extracted []v1beta1.TaskRunResult) []v1beta1.TaskRunResult {
+	byName := map[string]v1beta1.TaskRunResult{}
+	order := []string{}
+	for _, r := range append(existing, extracted...) {
+		if _, ok := byName[r.Name]; !ok {
+			order = append(order, r.Name)
+		}
+		byName[r.Name] = r
+	}
+	merged := make([]v1beta1.TaskRunResult, 0, len(order))
+	for _, name := range order {
+		merged = append(merged, byName[name])
+	}
+	return merged
+}
@@ -0,0 +1,91 @@
+package taskrun
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+func TestDecodeSidecarResults(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		lines         []string
+		wantResults   []v1beta1.TaskRunResult
+		wantMalformed int
+		wantErr       bool
+	}{
+		{
+			name: "decodes and dedupes by step and result name",
+			lines: []string{
+				`{"stepName":"build","resultName":"digest","value":"sha256:first","type":"string"}`,
+				`{"stepName":"build","resultName":"digest","value":"sha256:second","type":"string"}`,
+				`{"stepName":"build","resultName":"other","value":"val","type":"string"}`,
+			},
+			wantResults: []v1beta1.TaskRunResult{
+				{Name: "digest", Type: v1beta1.ResultsTypeString, Value: *v1beta1.NewStructuredValues("sha256:second")},
+				{Name: "other", Type: v1beta1.ResultsTypeString, Value: *v1beta1.NewStructuredValues("val")},
+			},
+		},
+		{
+			name: "skips malformed lines but keeps decoding",
+			lines: []string{
+				`not json`,
+				`{"stepName":"build","resultName":"digest","value":"sha256:abc","type":"string"}`,
+			},
+			wantResults: []v1beta1.TaskRunResult{
+				{Name: "digest", Type: v1beta1.ResultsTypeString, Value: *v1beta1.NewStructuredValues("sha256:abc")},
+			},
+			wantMalformed: 1,
+		},
+		{
+			name: "stops and returns an error once a single result exceeds the max size",
+			lines: []string{
+				`{"stepName":"build","resultName":"small","value":"ok","type":"string"}`,
+				`{"stepName":"build","resultName":"big","value":"` + strings.Repeat("a", maxResultSize+1) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"unread","value":"ok","type":"string"}`,
+			},
+			wantResults: []v1beta1.TaskRunResult{
+				{Name: "small", Type: v1beta1.ResultsTypeString, Value: *v1beta1.NewStructuredValues("ok")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "stops and returns an error once the cumulative size exceeds the max",
+			lines: []string{
+				`{"stepName":"build","resultName":"a","value":"` + strings.Repeat("a", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"b","value":"` + strings.Repeat("b", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"c","value":"` + strings.Repeat("c", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"d","value":"` + strings.Repeat("d", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"e","value":"` + strings.Repeat("e", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"f","value":"` + strings.Repeat("f", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"g","value":"` + strings.Repeat("g", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"h","value":"` + strings.Repeat("h", maxResultSize) + `","type":"string"}`,
+				`{"stepName":"build","resultName":"i","value":"` + strings.Repeat("i", maxResultSize) + `","type":"string"}`,
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sc := bufio.NewScanner(strings.NewReader(strings.Join(tc.lines, "\n")))
+			results, malformed, err := decodeSidecarResults(sc)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("decodeSidecarResults() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if malformed != tc.wantMalformed {
+				t.Errorf("malformed = %d, want %d", malformed, tc.wantMalformed)
+			}
+			if tc.wantResults != nil {
+				if len(results) != len(tc.wantResults) {
+					t.Fatalf("got %d results, want %d: %+v", len(results), len(tc.wantResults), results)
+				}
+				for i, want := range tc.wantResults {
+					if results[i].Name != want.Name || results[i].Value.StringVal != want.Value.StringVal {
+						t.Errorf("result[%d] = %+v, want %+v", i, results[i], want)
+					}
+				}
+			}
+		})
+	}
+}